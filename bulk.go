@@ -0,0 +1,141 @@
+package whois
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultBulkConcurrency is the worker pool size FetchMany uses when
+// BulkOptions.Concurrency is zero or negative.
+const DefaultBulkConcurrency = 10
+
+// BulkOptions configures Client.FetchMany.
+type BulkOptions struct {
+	// Concurrency bounds how many queries FetchMany has in flight at once,
+	// across all hosts. Zero or negative means DefaultBulkConcurrency.
+	Concurrency int
+
+	// PerHostQPS caps how many requests per second FetchMany sends to any
+	// single resolved host, so a bulk sweep doesn't get the caller banned
+	// by e.g. RIPE or ARIN. Zero means unlimited.
+	PerHostQPS float64
+}
+
+// BulkResult is one query's outcome from Client.FetchMany.
+type BulkResult struct {
+	// Query is the original string passed to FetchMany, so callers can
+	// match a result back to its request.
+	Query string
+
+	Response *Response
+	Err      error
+}
+
+// FetchMany resolves and fetches every query in queries concurrently,
+// streaming each BulkResult on the returned channel as soon as it completes
+// rather than waiting for the whole batch. Work is spread across
+// opts.Concurrency workers, and requests to any one resolved host are
+// throttled to opts.PerHostQPS via a token-bucket limiter shared by every
+// query that resolves to that host. Canceling ctx stops new work from being
+// started and drains the in-flight requests; the channel is always closed
+// once every in-flight fetch has returned. Callers that stop reading the
+// channel before it closes must cancel ctx, or the workers still waiting to
+// send their results will block forever.
+func (c *Client) FetchMany(ctx context.Context, queries []string, opts BulkOptions) <-chan BulkResult {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBulkConcurrency
+	}
+	limiters := newHostLimiters(opts.PerHostQPS)
+
+	work := make(chan string)
+	results := make(chan BulkResult)
+
+	var workers sync.WaitGroup
+	workers.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer workers.Done()
+			for query := range work {
+				res := c.fetchBulkOne(ctx, query, limiters)
+				select {
+				case results <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(work)
+		for _, query := range queries {
+			select {
+			case work <- query:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// fetchBulkOne resolves query, waits its turn on the resolved host's rate
+// limiter, and fetches it.
+func (c *Client) fetchBulkOne(ctx context.Context, query string, limiters *hostLimiters) BulkResult {
+	req, err := c.ResolveContext(ctx, query)
+	if err != nil {
+		return BulkResult{Query: query, Err: err}
+	}
+	if err := limiters.wait(ctx, req.Host); err != nil {
+		return BulkResult{Query: query, Err: err}
+	}
+	res, err := c.FetchContext(ctx, req)
+	return BulkResult{Query: query, Response: res, Err: err}
+}
+
+// hostLimiters lazily creates and guards a rate.Limiter per destination
+// host, so FetchMany's workers can share one limiter per host instead of
+// racing to create duplicates.
+type hostLimiters struct {
+	qps float64
+
+	mu     sync.Mutex
+	byHost map[string]*rate.Limiter
+}
+
+func newHostLimiters(qps float64) *hostLimiters {
+	return &hostLimiters{qps: qps, byHost: map[string]*rate.Limiter{}}
+}
+
+// wait blocks until host's token bucket has a token to spend, or returns
+// ctx.Err() if ctx is canceled first. It is a no-op when qps is unset.
+func (h *hostLimiters) wait(ctx context.Context, host string) error {
+	if h.qps <= 0 {
+		return nil
+	}
+	return h.limiterFor(host).Wait(ctx)
+}
+
+func (h *hostLimiters) limiterFor(host string) *rate.Limiter {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	l, ok := h.byHost[host]
+	if !ok {
+		burst := int(h.qps)
+		if burst < 1 {
+			burst = 1
+		}
+		l = rate.NewLimiter(rate.Limit(h.qps), burst)
+		h.byHost[host] = l
+	}
+	return l
+}
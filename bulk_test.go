@@ -0,0 +1,73 @@
+package whois
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHostLimitersReuseOneLimiterPerHost(t *testing.T) {
+	h := newHostLimiters(1000)
+	if h.limiterFor("a") != h.limiterFor("a") {
+		t.Fatal("expected limiterFor to return the same *rate.Limiter for repeat calls with the same host")
+	}
+	if h.limiterFor("a") == h.limiterFor("b") {
+		t.Fatal("expected limiterFor to give different hosts their own *rate.Limiter")
+	}
+}
+
+// primeBootstrap seeds b's cache directly so RDAP.Resolve can be exercised
+// against ts without FetchMany's workers making a live request to IANA.
+func primeBootstrap(b *bootstrapFile, rules map[string]string) {
+	b.mu.Lock()
+	b.rules = rules
+	b.fetched = time.Now()
+	b.mu.Unlock()
+}
+
+func TestFetchManyBoundsConcurrency(t *testing.T) {
+	const limit = 3
+	var inFlight, maxInFlight int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		json.NewEncoder(w).Encode(RDAPObject{ObjectClassName: "ip network"})
+	}))
+	defer ts.Close()
+
+	primeBootstrap(ipv4Bootstrap, map[string]string{"192.0.2.0/24": ts.URL})
+
+	c := NewClient(time.Second)
+	c.Mode = RDAPOnly
+
+	queries := make([]string, 4*limit)
+	for i := range queries {
+		queries[i] = "192.0.2." + strconv.Itoa(i+1)
+	}
+
+	got := 0
+	for res := range c.FetchMany(context.Background(), queries, BulkOptions{Concurrency: limit}) {
+		if res.Err != nil {
+			t.Fatalf("unexpected error for %s: %v", res.Query, res.Err)
+		}
+		got++
+	}
+	if got != len(queries) {
+		t.Fatalf("got %d results, want %d", got, len(queries))
+	}
+	if max := atomic.LoadInt32(&maxInFlight); max > limit {
+		t.Fatalf("observed %d requests in flight at once, want <= %d", max, limit)
+	}
+}
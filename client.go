@@ -2,20 +2,21 @@ package whois
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
-	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
-	log "github.com/Sirupsen/logrus"
-
-	"github.com/chendrak/socks"
+	"golang.org/x/net/proxy"
 )
 
 const (
@@ -30,7 +31,25 @@ const (
 // some whois Requests.
 type Client struct {
 	httpClient *http.Client
-	dialFunc   func(network, addr string) (net.Conn, error)
+	dialFunc   func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// Mode selects whether FetchContext uses classic whois, RDAP, or a
+	// combination of the two. The zero value is WhoisOnly.
+	Mode Mode
+
+	// Recursive, when true, makes FetchContext chase "thick whois"
+	// referrals (e.g. a registry's "Registrar WHOIS Server:" line) after a
+	// successful whois fetch, recording the chain on Response.Referrals.
+	Recursive bool
+
+	// MaxReferrals bounds how many referral hops FetchContext chases when
+	// Recursive is set. Zero or negative means DefaultMaxReferrals.
+	MaxReferrals int
+
+	// RetryPolicy controls whether and how FetchContext re-issues a
+	// request after a transient failure or a known rate-limit response.
+	// The zero value disables retries.
+	RetryPolicy RetryPolicy
 }
 
 type DefaultTimeoutDialer struct {
@@ -43,54 +62,77 @@ var DefaultClient = NewClient(DefaultTimeout)
 
 // NewClient creates and initializes a new Client with the specified timeout.
 func NewClient(timeout time.Duration) *Client {
-	return NewClientWithProxy(timeout, "")
+	return NewClientWithProxy(timeout, nil)
 }
 
-// NewClientWithProxy creates and initializes a new Client with the specified timeout.
-// Additionally, it initializes the internal proxy. The provided proxy must be a SOCKS proxy.
-// If the proxy string includes the scheme (like socks5://127.0.0.1:1234), it will be used, otherwise it defaults to SOCKS4
-func NewClientWithProxy(timeout time.Duration, proxy string) *Client {
-	var proxyFunc func(*http.Request) (*url.URL, error)
-	var dialFunc func(string, string) (net.Conn, error)
-
-	if proxy != "" {
-		proxyURL, err := url.Parse(proxy)
-		if err != nil {
-			log.Debugf("Error parsing URL (%s): %s", proxy, err)
-			proxyFunc = nil
-		} else {
-			proxyFunc = http.ProxyURL(proxyURL)
-
-			log.Debugf("Parsing URL (%s) successful! Host: %s\n", proxy, proxyURL.Host)
-
-			if strings.ToLower(proxyURL.Scheme) == "socks5" {
-				dialFunc = socks.DialSocksProxyTimeout(socks.SOCKS5, proxyURL.Host, timeout)
-			} else {
-				dialFunc = socks.DialSocksProxyTimeout(socks.SOCKS4, proxyURL.Host, timeout)
-			}
-		}
-	} else {
-		log.Debugf("Proxy string empty!")
-		dialer := &DefaultTimeoutDialer{timeout: timeout}
-		dialFunc = dialer.Dial
+// NewClientWithProxy creates and initializes a new Client with the specified
+// timeout, dialing through dialer instead of connecting directly. dialer may
+// be any golang.org/x/net/proxy.Dialer - a SOCKS5 dialer from proxy.SOCKS5
+// (which accepts username/password auth via the proxy URL's userinfo), an
+// HTTP CONNECT dialer, a PerHostProxy, or any other third-party
+// implementation. Dialers that also implement proxy.ContextDialer get the
+// request's context; plain Dialers are raced against it instead. A nil
+// dialer falls back to a direct, timeout-enforcing dialer. The same dialer
+// drives the transport used for HTTP-based servers like Cenpac.
+func NewClientWithProxy(timeout time.Duration, dialer proxy.Dialer) *Client {
+	if dialer == nil {
+		dialer = &DefaultTimeoutDialer{timeout: timeout}
+	}
+	dialFunc := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialContext(ctx, dialer, network, addr)
 	}
 
 	transport := &http.Transport{
-		Proxy:                 proxyFunc,
 		TLSHandshakeTimeout:   timeout,
 		ResponseHeaderTimeout: timeout,
+		DialContext:           dialFunc,
 	}
-	client := &Client{dialFunc: dialFunc}
-	transport.Dial = dialFunc
+	client := &Client{dialFunc: dialFunc, MaxReferrals: DefaultMaxReferrals}
 	client.httpClient = &http.Client{Transport: transport}
 	return client
 }
 
-// Dial implements the Dial interface, strictly enforcing that cumulative dial +
-// read time is limited to timeout. It applies to both whois and HTTP connections.
-func (c *DefaultTimeoutDialer) Dial(network, address string) (net.Conn, error) {
-	deadline := time.Now().Add(c.timeout)
-	conn, err := net.DialTimeout(network, address, c.timeout)
+// dialWithContext runs a blocking dial in a goroutine and returns as soon as
+// ctx is canceled, closing a late-arriving connection so it doesn't leak.
+func dialWithContext(ctx context.Context, dial func() (net.Conn, error)) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := dial()
+		done <- result{conn, err}
+	}()
+	select {
+	case r := <-done:
+		return r.conn, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// DialContext implements a timeout-enforcing dialer, strictly limiting
+// cumulative dial + read time to ctx's deadline, or to timeout when ctx has
+// no deadline, while also honoring ctx's cancellation.
+func (d *DefaultTimeoutDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	// A per-request deadline on ctx is a deliberate override of the
+	// client-wide timeout (shorter or longer); only fall back to the
+	// client's timeout when ctx carries no deadline of its own. Both the
+	// dial itself and the post-connect read must honor this same deadline,
+	// or a longer ctx deadline would still have the connect phase cut off
+	// early by a separate, fixed Timeout.
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(d.timeout)
+	}
+	dialer := &net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, network, address)
 	if err != nil {
 		return nil, err
 	}
@@ -98,31 +140,209 @@ func (c *DefaultTimeoutDialer) Dial(network, address string) (net.Conn, error) {
 	return conn, nil
 }
 
+// Dial implements the Dial interface, strictly enforcing that cumulative dial +
+// read time is limited to timeout. It applies to both whois and HTTP connections.
+func (d *DefaultTimeoutDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext dials address over network, honoring both the Client's
+// configured timeout/proxy and ctx's deadline or cancellation.
+func (c *Client) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return c.dialFunc(ctx, network, address)
+}
+
 // Dial implements the Dial interface, strictly enforcing that cumulative dial +
 // read time is limited to timeout. It applies to both whois and HTTP connections.
 func (c *Client) Dial(network, address string) (net.Conn, error) {
-	return c.dialFunc(network, address)
+	return c.DialContext(context.Background(), network, address)
 }
 
 // Fetch sends the Request to a whois server.
 func (c *Client) Fetch(req *Request) (*Response, error) {
+	return c.FetchContext(context.Background(), req)
+}
+
+// FetchContext sends the Request to a whois server, aborting as soon as ctx
+// is canceled or its deadline expires. If req.Hosts names more than one
+// candidate host, they are dialed simultaneously and the first successful,
+// non-empty response wins; the rest are canceled. If c.RetryPolicy allows
+// retries, a transient failure or a known rate-limit response is retried
+// with backoff before giving up.
+func (c *Client) FetchContext(ctx context.Context, req *Request) (*Response, error) {
+	res, err := c.fetchWithRetry(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Recursive && res.Format == FormatWhois {
+		c.chaseReferrals(ctx, req, res, map[string]bool{strings.ToLower(res.Host): true})
+	}
+	return res, nil
+}
+
+// fetchWithRetry runs fetchOnce, re-issuing the request per c.RetryPolicy
+// until it succeeds, is judged non-retryable, or the policy's MaxRetries is
+// exhausted. A successful Response that needed retries records how many
+// attempts it took and the errors that preceded it. A request that is still
+// retryable once MaxRetries is exhausted is reported as a failure rather
+// than returned as if it had succeeded.
+func (c *Client) fetchWithRetry(ctx context.Context, req *Request) (*Response, error) {
+	var errs []error
+	for attempt := 1; ; attempt++ {
+		res, err := c.fetchOnce(ctx, req)
+		if !c.RetryPolicy.retryable(res, err) {
+			if err != nil {
+				return nil, err
+			}
+			res.Attempts = attempt
+			res.Errors = errs
+			return res, nil
+		}
+		if err == nil {
+			err = retryReason(res)
+		}
+		if attempt > c.RetryPolicy.MaxRetries {
+			return nil, err
+		}
+		errs = append(errs, err)
+		if serr := sleepContext(ctx, c.RetryPolicy.backoff(attempt)); serr != nil {
+			return nil, serr
+		}
+	}
+}
+
+// fetchOnce dispatches req through RDAP, simultaneous whois, HTTP, or plain
+// whois/tcp/43, depending on c.Mode and req, with no retry logic of its own.
+func (c *Client) fetchOnce(ctx context.Context, req *Request) (*Response, error) {
+	// req.URL already names the exact resource to fetch (e.g. a link a
+	// prior Response.Follow chose), so go straight to HTTP instead of
+	// re-running RDAP bootstrap resolution, which would discard it and
+	// rebuild a fresh URL from req.Query alone.
 	if req.URL != "" {
-		return c.fetchHTTP(req)
+		return c.fetchHTTP(ctx, req)
+	}
+
+	if c.Mode != WhoisOnly {
+		res, err := c.fetchRDAP(ctx, req)
+		if c.Mode == RDAPOnly || err == nil {
+			return res, err
+		}
+		var resolveErr *rdapResolveError
+		if c.Mode == PreferRDAP && !errors.As(err, &resolveErr) {
+			// The bootstrap lookup found a server and the RDAP fetch
+			// itself is what failed; PreferRDAP reports that failure
+			// rather than silently falling back to whois.
+			return nil, err
+		}
+		// RDAPWithWhoisFallback always falls through to whois below;
+		// PreferRDAP falls through only when RDAP resolution itself failed:
+		// no bootstrap entry for this query, or the bootstrap file could
+		// not be fetched.
+	}
+
+	switch {
+	case len(req.Hosts) > 1:
+		return c.fetchSimultaneous(ctx, req)
+	case req.URL != "":
+		return c.fetchHTTP(ctx, req)
+	default:
+		return c.fetchWhois(ctx, req, req.Host)
 	}
-	return c.fetchWhois(req)
 }
 
-func (c *Client) fetchWhois(req *Request) (*Response, error) {
-	conn, err := c.Dial("tcp", req.Host+":43")
+// fetchRDAP resolves req.Query against the IANA bootstrap registries and
+// fetches the resulting RDAP URL, without mutating req itself. The bootstrap
+// lookup is done through c's own httpClient, so it honors the same
+// proxy/dialer as every other HTTP-based fetch c makes.
+func (c *Client) fetchRDAP(ctx context.Context, req *Request) (*Response, error) {
+	rreq := &Request{Query: req.Query}
+	if err := (&RDAP{HTTPClient: c.httpClient}).Resolve(ctx, rreq); err != nil {
+		return nil, &rdapResolveError{err}
+	}
+	return c.fetchHTTP(ctx, rreq)
+}
+
+// fetchSimultaneous dials every host in req.Hosts in parallel and returns the
+// first successful, non-empty Response. Canceling ctx as soon as one arrives
+// unblocks every other in-flight dial/read, and results is buffered for
+// every host, so the remaining goroutines finish shortly after the call
+// returns instead of leaking.
+func (c *Client) fetchSimultaneous(ctx context.Context, req *Request) (*Response, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type attempt struct {
+		res *Response
+		err error
+	}
+	results := make(chan attempt, len(req.Hosts))
+
+	var wg sync.WaitGroup
+	for _, host := range req.Hosts {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			res, err := c.fetchWhois(ctx, req, host)
+			results <- attempt{res, err}
+		}(host)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var lastErr error
+	var lastEmpty *Response
+	for a := range results {
+		if a.err == nil && len(a.res.Body) > 0 {
+			cancel()
+			return a.res, nil
+		}
+		if a.err != nil {
+			lastErr = a.err
+		} else {
+			lastEmpty = a.res
+		}
+	}
+	// Every host answered with an empty body rather than an error (seen
+	// from RIRs under load): return one of those responses rather than a
+	// bare error, so RetryPolicy's empty-body check can retry the query.
+	if lastEmpty != nil {
+		return lastEmpty, nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, errors.New("whois: no host returned a response for " + req.Query)
+}
+
+func (c *Client) fetchWhois(ctx context.Context, req *Request, host string) (*Response, error) {
+	conn, err := c.DialContext(ctx, "tcp", host+":43")
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Close()
+
+	// Unblock a pending Write/Read as soon as ctx is done, even though the
+	// dial itself has already returned.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
 	if _, err = conn.Write(req.Body); err != nil {
 		logError(err)
 		return nil, err
 	}
-	res := NewResponse(req.Query, req.Host)
+	res := NewResponse(req.Query, host)
+	res.client = c
+	res.Format = FormatWhois
 	if res.Body, err = ioutil.ReadAll(io.LimitReader(conn, DefaultReadLimit)); err != nil {
 		logError(err)
 		return nil, err
@@ -131,8 +351,8 @@ func (c *Client) fetchWhois(req *Request) (*Response, error) {
 	return res, nil
 }
 
-func (c *Client) fetchHTTP(req *Request) (*Response, error) {
-	hreq, err := httpRequest(req)
+func (c *Client) fetchHTTP(ctx context.Context, req *Request) (*Response, error) {
+	hreq, err := httpRequest(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -140,16 +360,29 @@ func (c *Client) fetchHTTP(req *Request) (*Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer hres.Body.Close()
 	res := NewResponse(req.Query, req.Host)
+	res.client = c
+	res.Format = req.Format
+	if res.Format == "" {
+		res.Format = FormatWhois
+	}
+	res.StatusCode = hres.StatusCode
 	if res.Body, err = ioutil.ReadAll(io.LimitReader(hres.Body, DefaultReadLimit)); err != nil {
 		logError(err)
 		return nil, err
 	}
 	res.DetectContentType(hres.Header.Get("Content-Type"))
+	if res.Format == FormatRDAP {
+		var obj RDAPObject
+		if err := json.Unmarshal(res.Body, &obj); err == nil {
+			res.RDAP = &obj
+		}
+	}
 	return res, nil
 }
 
-func httpRequest(req *Request) (*http.Request, error) {
+func httpRequest(ctx context.Context, req *Request) (*http.Request, error) {
 	var hreq *http.Request
 	var err error
 	// POST if non-zero Request.Body
@@ -161,6 +394,7 @@ func httpRequest(req *Request) (*http.Request, error) {
 	if err != nil {
 		return nil, err
 	}
+	hreq = hreq.WithContext(ctx)
 	// Some web whois servers require a Referer header
 	hreq.Header.Add("Referer", req.URL)
 	return hreq, nil
@@ -0,0 +1,88 @@
+package whois
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// newStubWhoisHost starts a loopback whois server that answers a single
+// connection with body (after delay) and returns its listener address.
+// Tests map a host alias to that address in their own Client's dialFunc, so
+// fetchSimultaneous's per-host fan-out can be exercised without touching
+// real whois servers.
+func newStubWhoisHost(t *testing.T, body []byte, delay time.Duration) (host string, ln net.Listener) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 256)
+		conn.Read(buf)
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+		conn.Write(body)
+	}()
+	return ln.Addr().String(), ln
+}
+
+func TestFetchSimultaneousReturnsFirstNonEmptyResponse(t *testing.T) {
+	slowHost, _ := newStubWhoisHost(t, []byte{}, 0)
+	fastHost, _ := newStubWhoisHost(t, []byte("authoritative"), 0)
+
+	addrs := map[string]string{"slow": slowHost, "fast": fastHost}
+	c := NewClient(time.Second)
+	c.dialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "tcp", addrs[hostFromAddr(addr)])
+	}
+
+	req := NewRequest("example.com")
+	req.Hosts = []string{"slow", "fast"}
+	res, err := c.fetchSimultaneous(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(res.Body) != "authoritative" {
+		t.Fatalf("got body %q, want %q", res.Body, "authoritative")
+	}
+}
+
+func TestFetchSimultaneousFallsBackToEmptyBodyWhenNoHostAnswers(t *testing.T) {
+	host, _ := newStubWhoisHost(t, []byte{}, 0)
+
+	addrs := map[string]string{"only": host}
+	c := NewClient(time.Second)
+	c.dialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "tcp", addrs[hostFromAddr(addr)])
+	}
+
+	req := NewRequest("example.com")
+	req.Hosts = []string{"only"}
+	res, err := c.fetchSimultaneous(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Body) != 0 {
+		t.Fatalf("got body %q, want empty", res.Body)
+	}
+}
+
+// hostFromAddr strips the ":43" port fetchWhois always dials, leaving the
+// bare host so the test's stub dialFunc can look it up.
+func hostFromAddr(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,65 @@
+package whois
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"golang.org/x/net/proxy"
+)
+
+// dialContext dials addr through dialer, honoring ctx. Dialers that
+// implement proxy.ContextDialer get ctx directly; plain proxy.Dialer
+// implementations have their blocking Dial raced against ctx.Done().
+func dialContext(ctx context.Context, dialer proxy.Dialer, network, addr string) (net.Conn, error) {
+	if cd, ok := dialer.(proxy.ContextDialer); ok {
+		return cd.DialContext(ctx, network, addr)
+	}
+	return dialWithContext(ctx, func() (net.Conn, error) {
+		return dialer.Dial(network, addr)
+	})
+}
+
+// PerHostProxy routes a dial to a different proxy.Dialer based on the
+// destination host, mirroring golang.org/x/net/proxy.PerHost but allowing
+// any number of per-host rules instead of a single bypass dialer. This lets
+// callers send, say, whois.arin.net and whois.ripe.net through different
+// proxies, or bypass the proxy entirely for a set of hosts by registering
+// proxy.Direct.
+type PerHostProxy struct {
+	def   proxy.Dialer
+	rules map[string]proxy.Dialer
+}
+
+// NewPerHostProxy creates a PerHostProxy that dials through def unless a
+// host-specific rule has been added with AddHost.
+func NewPerHostProxy(def proxy.Dialer) *PerHostProxy {
+	return &PerHostProxy{def: def, rules: map[string]proxy.Dialer{}}
+}
+
+// AddHost routes any dial to host through dialer instead of the default.
+func (p *PerHostProxy) AddHost(host string, dialer proxy.Dialer) *PerHostProxy {
+	p.rules[strings.ToLower(host)] = dialer
+	return p
+}
+
+// Dial implements proxy.Dialer.
+func (p *PerHostProxy) Dial(network, addr string) (net.Conn, error) {
+	return p.dialerFor(addr).Dial(network, addr)
+}
+
+// DialContext implements proxy.ContextDialer.
+func (p *PerHostProxy) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	return dialContext(ctx, p.dialerFor(addr), network, addr)
+}
+
+func (p *PerHostProxy) dialerFor(addr string) proxy.Dialer {
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	if dialer, ok := p.rules[strings.ToLower(host)]; ok {
+		return dialer
+	}
+	return p.def
+}
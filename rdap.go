@@ -0,0 +1,299 @@
+package whois
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Format identifies the wire format of a Request/Response pair.
+type Format string
+
+const (
+	// FormatWhois is the classic line-oriented whois protocol.
+	FormatWhois Format = "whois"
+	// FormatRDAP is JSON over HTTP, per RFC 7482/9082.
+	FormatRDAP Format = "rdap"
+)
+
+// Mode selects which transport Client.FetchContext uses for a query. A
+// Request that already carries a URL is fetched from that URL over HTTP
+// whatever the Mode.
+type Mode int
+
+const (
+	// WhoisOnly always uses the classic whois transport. This is the
+	// zero value, so existing callers are unaffected.
+	WhoisOnly Mode = iota
+	// RDAPOnly always resolves and fetches over RDAP, failing if no
+	// bootstrap entry matches the query.
+	RDAPOnly
+	// PreferRDAP uses RDAP when a bootstrap entry matches the query, and
+	// returns its error rather than falling back to whois if the RDAP
+	// fetch itself fails.
+	PreferRDAP
+	// RDAPWithWhoisFallback uses RDAP when a bootstrap entry matches, and
+	// retries over whois if the RDAP fetch fails.
+	RDAPWithWhoisFallback
+)
+
+// RDAPLink is a single entry in an RDAP object's "links" array.
+type RDAPLink struct {
+	Rel  string `json:"rel"`
+	Href string `json:"href"`
+}
+
+// RDAPObject is a minimal parse of an RDAP response body: just enough to
+// identify the object and follow referral links. Callers that need the full
+// response (entities, events, remarks, ...) should unmarshal Response.Body
+// themselves.
+type RDAPObject struct {
+	ObjectClassName string     `json:"objectClassName"`
+	Handle          string     `json:"handle"`
+	Links           []RDAPLink `json:"links"`
+}
+
+// Follow fetches the first "related" link in r, which RDAP servers use to
+// point at a more authoritative or more detailed object (e.g. a thin
+// registry referring to a registrar's RDAP server). It returns nil, nil if r
+// is not an RDAP response or carries no related link. The request is sent
+// through the Client that produced r, so it inherits that Client's Mode,
+// proxy, timeout, and RetryPolicy; if r wasn't produced by a Client, it
+// falls back to DefaultClient.
+func (r *Response) Follow(ctx context.Context) (*Response, error) {
+	if r.Format != FormatRDAP || r.RDAP == nil {
+		return nil, nil
+	}
+	client := r.client
+	if client == nil {
+		client = DefaultClient
+	}
+	for _, link := range r.RDAP.Links {
+		if link.Rel != "related" {
+			continue
+		}
+		req := &Request{Query: r.Query, Host: r.Host, URL: link.Href, Format: FormatRDAP}
+		return client.FetchContext(ctx, req)
+	}
+	return nil, nil
+}
+
+// RDAP resolves a query against the IANA RDAP bootstrap registries
+// (dns.json, ipv4.json, ipv6.json, asn.json), filling in req.URL and
+// req.Format so Client.Fetch can drive the lookup over HTTP.
+type RDAP struct {
+	// HTTPClient fetches the IANA bootstrap files. A nil HTTPClient uses
+	// http.DefaultClient, which does not honor any Client's configured
+	// proxy/dialer; Client.FetchContext always passes its own httpClient.
+	HTTPClient *http.Client
+}
+
+// Resolve implements Server.
+func (s *RDAP) Resolve(ctx context.Context, req *Request) error {
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var base, path string
+	var err error
+
+	switch {
+	case looksLikeASN(req.Query):
+		var asn uint32
+		asn, err = parseASNQuery(req.Query)
+		if err == nil {
+			base, err = asnBootstrap.lookupASN(ctx, client, asn)
+		}
+		path = "autnums/" + strconv.FormatUint(uint64(asn), 10)
+	case net.ParseIP(req.Query) != nil:
+		ip := net.ParseIP(req.Query)
+		if ip.To4() != nil {
+			base, err = ipv4Bootstrap.lookupCIDR(ctx, client, ip)
+		} else {
+			base, err = ipv6Bootstrap.lookupCIDR(ctx, client, ip)
+		}
+		path = "ip/" + req.Query
+	default:
+		labels := strings.Split(req.Query, ".")
+		tld := labels[len(labels)-1]
+		base, err = dnsBootstrap.lookup(ctx, client, tld)
+		path = "domain/" + req.Query
+	}
+	if err != nil {
+		return err
+	}
+
+	req.URL = strings.TrimRight(base, "/") + "/" + path
+	req.Format = FormatRDAP
+	req.Body = nil
+	return nil
+}
+
+// rdapResolveError wraps a failure of RDAP resolution for a query (fetching
+// the bootstrap file or finding an entry in it, i.e. Server.Resolve
+// itself), as distinct from a failure of the
+// subsequent RDAP HTTP fetch. Client.fetchOnce uses errors.As to tell the
+// two apart so PreferRDAP can fall back to whois only for the former.
+type rdapResolveError struct {
+	err error
+}
+
+func (e *rdapResolveError) Error() string { return e.err.Error() }
+func (e *rdapResolveError) Unwrap() error { return e.err }
+
+func looksLikeASN(q string) bool {
+	_, err := parseASNQuery(q)
+	return err == nil
+}
+
+func parseASNQuery(q string) (uint32, error) {
+	n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(q), "AS"), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("rdap: %q is not an AS number", q)
+	}
+	return uint32(n), nil
+}
+
+// bootstrapTTL controls how long a fetched IANA bootstrap file is reused
+// before it's refetched on the next lookup.
+const bootstrapTTL = 24 * time.Hour
+
+var (
+	dnsBootstrap  = &bootstrapFile{url: "https://data.iana.org/rdap/dns.json"}
+	ipv4Bootstrap = &bootstrapFile{url: "https://data.iana.org/rdap/ipv4.json"}
+	ipv6Bootstrap = &bootstrapFile{url: "https://data.iana.org/rdap/ipv6.json"}
+	asnBootstrap  = &bootstrapFile{url: "https://data.iana.org/rdap/asn.json"}
+)
+
+// bootstrapFile caches one of IANA's RDAP bootstrap registries in memory,
+// refreshing it lazily once bootstrapTTL has elapsed since the last fetch.
+type bootstrapFile struct {
+	url string
+
+	mu      sync.RWMutex
+	fetched time.Time
+	rules   map[string]string // lookup key (tld, CIDR, or ASN range) -> RDAP base URL
+}
+
+type bootstrapDoc struct {
+	Services [][][]string `json:"services"`
+}
+
+func (b *bootstrapFile) refresh(ctx context.Context, client *http.Client) error {
+	b.mu.RLock()
+	fresh := time.Since(b.fetched) < bootstrapTTL
+	b.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	hreq, err := http.NewRequestWithContext(ctx, "GET", b.url, nil)
+	if err != nil {
+		return err
+	}
+	hres, err := client.Do(hreq)
+	if err != nil {
+		return err
+	}
+	defer hres.Body.Close()
+
+	var doc bootstrapDoc
+	if err := json.NewDecoder(hres.Body).Decode(&doc); err != nil {
+		return err
+	}
+
+	rules := make(map[string]string, len(doc.Services))
+	for _, svc := range doc.Services {
+		if len(svc) != 2 || len(svc[1]) == 0 {
+			continue
+		}
+		for _, key := range svc[0] {
+			rules[strings.ToLower(key)] = svc[1][0]
+		}
+	}
+
+	b.mu.Lock()
+	b.rules = rules
+	b.fetched = time.Now()
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *bootstrapFile) lookup(ctx context.Context, client *http.Client, key string) (string, error) {
+	if err := b.refresh(ctx, client); err != nil {
+		return "", err
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if base, ok := b.rules[strings.ToLower(key)]; ok {
+		return base, nil
+	}
+	return "", fmt.Errorf("rdap: no bootstrap entry for %q", key)
+}
+
+// lookupCIDR returns the base URL of the most specific (longest prefix)
+// bootstrap entry whose CIDR block contains ip.
+func (b *bootstrapFile) lookupCIDR(ctx context.Context, client *http.Client, ip net.IP) (string, error) {
+	if err := b.refresh(ctx, client); err != nil {
+		return "", err
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var best string
+	bestOnes := -1
+	for cidr, base := range b.rules {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil || !network.Contains(ip) {
+			continue
+		}
+		if ones, _ := network.Mask.Size(); ones > bestOnes {
+			bestOnes, best = ones, base
+		}
+	}
+	if bestOnes < 0 {
+		return "", fmt.Errorf("rdap: no bootstrap entry covers %s", ip)
+	}
+	return best, nil
+}
+
+// lookupASN returns the base URL of the bootstrap entry whose range ("lo-hi"
+// or a single number) contains asn.
+func (b *bootstrapFile) lookupASN(ctx context.Context, client *http.Client, asn uint32) (string, error) {
+	if err := b.refresh(ctx, client); err != nil {
+		return "", err
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for rng, base := range b.rules {
+		lo, hi, ok := parseASNRange(rng)
+		if ok && asn >= lo && asn <= hi {
+			return base, nil
+		}
+	}
+	return "", fmt.Errorf("rdap: no bootstrap entry covers AS%d", asn)
+}
+
+func parseASNRange(s string) (lo, hi uint32, ok bool) {
+	parts := strings.SplitN(s, "-", 2)
+	a, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	if len(parts) == 1 {
+		return uint32(a), uint32(a), true
+	}
+	b2, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	return uint32(a), uint32(b2), true
+}
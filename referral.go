@@ -0,0 +1,105 @@
+package whois
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// DefaultMaxReferrals bounds how many referral hops Client.Fetch will chase
+// when Client.Recursive is set.
+const DefaultMaxReferrals = 3
+
+// referralPattern matches the line formats whois servers use to point at a
+// more authoritative server: a thin gTLD registry's "Registrar WHOIS
+// Server:", an RIR's "ReferralServer:" (an rwhois:// URL), or the bare
+// "whois:" field used by RIPE/APNIC/AFRINIC-style output.
+var referralPattern = regexp.MustCompile(`(?im)^\s*(?:Registrar WHOIS Server|ReferralServer|whois)\s*:\s*(\S+)\s*$`)
+
+// referralHost extracts a referral target from body, if any, stripping a
+// scheme (e.g. rwhois://), path, and port so the result can be dialed on
+// :43.
+func referralHost(body []byte) (string, bool) {
+	m := referralPattern.FindSubmatch(body)
+	if m == nil {
+		return "", false
+	}
+	host := string(m[1])
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexByte(host, '/'); i >= 0 {
+		host = host[:i]
+	}
+	if i := strings.IndexByte(host, ':'); i >= 0 {
+		host = host[:i]
+	}
+	if host == "" {
+		return "", false
+	}
+	return host, true
+}
+
+// chaseReferrals follows the "thick whois" referral chain starting from res,
+// appending each hop to res.Referrals. visited guards against loops (it must
+// already contain res.Host), MaxReferrals bounds the chain length, and ctx's
+// deadline/cancellation applies across every hop, not just the first.
+func (c *Client) chaseReferrals(ctx context.Context, req *Request, res *Response, visited map[string]bool) {
+	max := c.MaxReferrals
+	if max <= 0 {
+		max = DefaultMaxReferrals
+	}
+
+	cur := res
+	for i := 0; i < max; i++ {
+		host, ok := referralHost(cur.Body)
+		if !ok {
+			return
+		}
+		host = strings.ToLower(host)
+		if visited[host] {
+			return
+		}
+		visited[host] = true
+
+		if ctx.Err() != nil {
+			return
+		}
+		next, err := c.fetchWhois(ctx, req, host)
+		if err != nil {
+			return
+		}
+		res.Referrals = append(res.Referrals, next)
+		cur = next
+	}
+}
+
+// WhoisRecursive queries a whois server for q like Whois, but chases any
+// referral to a more authoritative server (see Client.Recursive) and
+// returns the last hop's body rather than the thin registry's. The full
+// chain, including every intermediate hop, is available on the underlying
+// Response, which callers that need it can fetch directly via a Client with
+// Recursive set.
+func WhoisRecursive(q string) (string, error) {
+	return WhoisRecursiveContext(context.Background(), q)
+}
+
+// WhoisRecursiveContext is WhoisRecursive with an explicit context.
+func WhoisRecursiveContext(ctx context.Context, q string) (string, error) {
+	req, err := ResolveContext(ctx, q)
+	if err != nil {
+		return "", err
+	}
+
+	client := *DefaultClient
+	client.Recursive = true
+	res, err := client.FetchContext(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	if n := len(res.Referrals); n > 0 {
+		return string(res.Referrals[n-1].Body), nil
+	}
+	return string(res.Body), nil
+}
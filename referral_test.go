@@ -0,0 +1,86 @@
+package whois
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChaseReferralsFollowsToTheAuthoritativeHop(t *testing.T) {
+	hostBAddr, _ := newStubWhoisHost(t, []byte("final data\r\n"), 0)
+	hostAAddr, _ := newStubWhoisHost(t, []byte("Registrar WHOIS Server: hostb\r\n"), 0)
+
+	addrs := map[string]string{"hosta": hostAAddr, "hostb": hostBAddr}
+	c := NewClient(time.Second)
+	c.Recursive = true
+	c.dialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "tcp", addrs[hostFromAddr(addr)])
+	}
+
+	req := NewRequest("example.com")
+	req.Host = "hosta"
+	res, err := c.FetchContext(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(res.Body), "Registrar WHOIS Server") {
+		t.Fatalf("expected the thin registry's own body on Response.Body, got %q", res.Body)
+	}
+	if len(res.Referrals) != 1 {
+		t.Fatalf("got %d referrals, want 1", len(res.Referrals))
+	}
+	if string(res.Referrals[0].Body) != "final data\r\n" {
+		t.Fatalf("got referral body %q, want %q", res.Referrals[0].Body, "final data\r\n")
+	}
+}
+
+func TestChaseReferralsStopsOnACycle(t *testing.T) {
+	// hostb refers back to hosta, which chaseReferrals must not revisit.
+	hostBAddr, _ := newStubWhoisHost(t, []byte("Registrar WHOIS Server: hosta\r\n"), 0)
+	hostAAddr, _ := newStubWhoisHost(t, []byte("Registrar WHOIS Server: hostb\r\n"), 0)
+
+	addrs := map[string]string{"hosta": hostAAddr, "hostb": hostBAddr}
+	c := NewClient(time.Second)
+	c.Recursive = true
+	c.MaxReferrals = 5
+	c.dialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "tcp", addrs[hostFromAddr(addr)])
+	}
+
+	req := NewRequest("example.com")
+	req.Host = "hosta"
+	res, err := c.FetchContext(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Referrals) != 1 {
+		t.Fatalf("got %d referrals, want the cycle back to hosta to stop the chain after 1 hop", len(res.Referrals))
+	}
+}
+
+func TestChaseReferralsRespectsMaxReferrals(t *testing.T) {
+	h4, _ := newStubWhoisHost(t, []byte("host4 data\r\n"), 0)
+	h3, _ := newStubWhoisHost(t, []byte("Registrar WHOIS Server: host4\r\n"), 0)
+	h2, _ := newStubWhoisHost(t, []byte("Registrar WHOIS Server: host3\r\n"), 0)
+	h1, _ := newStubWhoisHost(t, []byte("Registrar WHOIS Server: host2\r\n"), 0)
+
+	addrs := map[string]string{"host1": h1, "host2": h2, "host3": h3, "host4": h4}
+	c := NewClient(time.Second)
+	c.Recursive = true
+	c.MaxReferrals = 2
+	c.dialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "tcp", addrs[hostFromAddr(addr)])
+	}
+
+	req := NewRequest("example.com")
+	req.Host = "host1"
+	res, err := c.FetchContext(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Referrals) != 2 {
+		t.Fatalf("got %d referrals, want MaxReferrals=2 to cap the chain at 2 hops", len(res.Referrals))
+	}
+}
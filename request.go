@@ -0,0 +1,37 @@
+package whois
+
+// Request represents a whois query to be sent to a whois server.
+type Request struct {
+	// Query is the string originally passed to Resolve or Whois.
+	Query string
+
+	// Host is the primary whois server host that will receive the query.
+	// RDAP resolution leaves it unchanged and names the RDAP server in URL.
+	Host string
+
+	// Hosts, when set, lists every candidate host (Host plus any mirrors)
+	// that Client.FetchContext should dial simultaneously, using the first
+	// successful non-empty response and canceling the rest.
+	Hosts []string
+
+	// URL is set for HTTP-based lookups (e.g. RDAP or web whois gateways).
+	// When empty, Fetch issues a plain whois/tcp/43 request instead, or an
+	// RDAP lookup if Client.Mode is not WhoisOnly.
+	URL string
+
+	// Format records which protocol URL speaks, so Client.Fetch knows how to
+	// parse the response. Left zero-valued, it defaults to FormatWhois.
+	Format Format
+
+	// Body is written to the whois connection, or used as the HTTP request
+	// body.
+	Body []byte
+}
+
+// NewRequest creates a new Request for the given query.
+func NewRequest(query string) *Request {
+	return &Request{
+		Query: query,
+		Body:  []byte(query + "\r\n"),
+	}
+}
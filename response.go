@@ -0,0 +1,67 @@
+package whois
+
+import (
+	"net/http"
+	"time"
+)
+
+// Response represents the result of a whois (or RDAP) query.
+type Response struct {
+	Query     string
+	Host      string
+	FetchedAt time.Time
+	MediaType string
+	Body      []byte
+
+	// Format is FormatWhois or FormatRDAP, depending on which transport
+	// produced Body.
+	Format Format
+
+	// RDAP holds the parsed JSON body when Format is FormatRDAP.
+	RDAP *RDAPObject
+
+	// Referrals holds the chain of follow-up whois queries Client.Fetch
+	// made on Client.Recursive's behalf, in the order they were queried.
+	Referrals []*Response
+
+	// StatusCode is the HTTP status code for HTTP-based fetches (RDAP,
+	// Cenpac); it is always zero for classic whois/tcp/43 responses.
+	StatusCode int
+
+	// Attempts counts how many times Client.Fetch dialed before returning
+	// this Response, including the first try. It is 1 unless
+	// Client.RetryPolicy caused one or more retries.
+	Attempts int
+
+	// Errors holds one entry per attempt that Client.RetryPolicy decided
+	// to retry, oldest first, so callers can see why a query took several
+	// tries even though it eventually succeeded. An attempt retried
+	// because of the response itself (an empty body, HTTP 429, a
+	// rate-limit signature) rather than a Go error records that reason
+	// here instead of a nil entry.
+	Errors []error
+
+	// client is the Client that produced this Response, so Follow can
+	// chase a referral link through the same Mode/proxy/timeout/
+	// RetryPolicy instead of reaching for DefaultClient.
+	client *Client
+}
+
+// NewResponse creates a new Response for the given query and host.
+func NewResponse(query, host string) *Response {
+	return &Response{
+		Query:     query,
+		Host:      host,
+		FetchedAt: time.Now(),
+	}
+}
+
+// DetectContentType records the response's media type, preferring an
+// explicit Content-Type header and falling back to sniffing the body.
+func (r *Response) DetectContentType(contentType string) {
+	if contentType != "" {
+		r.MediaType = contentType
+		return
+	}
+	r.MediaType = http.DetectContentType(r.Body)
+}
@@ -0,0 +1,156 @@
+package whois
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// DefaultInitialBackoff and DefaultMaxBackoff bound RetryPolicy's backoff
+// when a policy leaves them unset.
+const (
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 30 * time.Second
+)
+
+// RetryPolicy configures how Client.FetchContext re-issues a request after a
+// transient failure or a known rate-limit response. The zero value disables
+// retries entirely (MaxRetries defaults to 0), so existing callers are
+// unaffected.
+type RetryPolicy struct {
+	// MaxRetries is the number of additional attempts after the first.
+	// Zero (the default) means no retries.
+	MaxRetries int
+
+	// InitialBackoff is the delay before the first retry. Zero means
+	// DefaultInitialBackoff.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries. Zero means
+	// DefaultMaxBackoff.
+	MaxBackoff time.Duration
+
+	// Jitter adds up to ±Jitter of random variance to each backoff, to
+	// avoid a thundering herd of retries. Zero disables jitter.
+	Jitter time.Duration
+
+	// Retryable decides whether a failed attempt should be retried. A nil
+	// Retryable uses defaultRetryable, which recognizes timeouts,
+	// connection resets, empty bodies, HTTP 429, and known rate-limit
+	// wording (e.g. ARIN's "exceeded the maximum allowable number").
+	Retryable func(res *Response, err error) bool
+}
+
+func (p RetryPolicy) retryable(res *Response, err error) bool {
+	if p.MaxRetries <= 0 {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(res, err)
+	}
+	return defaultRetryable(res, err)
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = DefaultInitialBackoff
+	}
+	max := p.MaxBackoff
+	if max <= 0 {
+		max = DefaultMaxBackoff
+	}
+
+	d := initial * time.Duration(int64(1)<<uint(attempt-1))
+	if d <= 0 || d > max {
+		d = max
+	}
+	if p.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(p.Jitter)*2+1)) - p.Jitter
+		if d < 0 {
+			d = 0
+		}
+	}
+	return d
+}
+
+// defaultRetryable reports whether a failed whois/RDAP attempt looks
+// transient and worth retrying: a network timeout, a connection reset, an
+// empty body (whois servers, notably ARIN, sometimes drop the connection
+// under load instead of returning an error), an HTTP 429, or wording known
+// to signal a rate limit.
+func defaultRetryable(res *Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return true
+		}
+		if errors.Is(err, syscall.ECONNRESET) {
+			return true
+		}
+		if errors.Is(err, io.EOF) && (res == nil || len(res.Body) == 0) {
+			return true
+		}
+		return false
+	}
+	if res == nil {
+		return false
+	}
+	if res.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if len(res.Body) == 0 {
+		return true
+	}
+	for _, sig := range rateLimitSignatures {
+		if bytes.Contains(res.Body, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimitSignatures are substrings whois servers are known to include in
+// the body of a rate-limited response rather than, or in addition to, a
+// machine-readable status.
+var rateLimitSignatures = [][]byte{
+	[]byte("exceeded the maximum allowable number"),
+}
+
+// retryReason describes why a retried attempt was judged retryable when the
+// fetch itself returned no error, so it can stand in for err on
+// Response.Errors instead of a nil entry.
+func retryReason(res *Response) error {
+	switch {
+	case res == nil:
+		return errors.New("whois: retry: no response")
+	case res.StatusCode == http.StatusTooManyRequests:
+		return errors.New("whois: retry: HTTP 429 Too Many Requests")
+	case len(res.Body) == 0:
+		return errors.New("whois: retry: empty response body")
+	default:
+		return errors.New("whois: retry: response matched a rate-limit signature")
+	}
+}
+
+// sleepContext waits for d, or returns ctx.Err() as soon as ctx is done,
+// whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
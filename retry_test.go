@@ -0,0 +1,95 @@
+package whois
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// newStubWhoisServer starts a loopback whois server that replies to each
+// accepted connection with the next entry in bodies (an empty entry
+// simulates a server that drops the connection without writing anything),
+// and returns a Client whose dialFunc always dials it regardless of the
+// requested host.
+func newStubWhoisServer(t *testing.T, bodies [][]byte) *Client {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	i := 0
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			buf := make([]byte, 256)
+			conn.Read(buf)
+			if i < len(bodies) {
+				conn.Write(bodies[i])
+				i++
+			}
+			conn.Close()
+		}
+	}()
+
+	c := NewClient(time.Second)
+	c.dialFunc = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, "tcp", ln.Addr().String())
+	}
+	return c
+}
+
+func TestFetchWithRetryZeroValuePolicyReturnsEmptyBodyAsSuccess(t *testing.T) {
+	c := newStubWhoisServer(t, [][]byte{{}})
+	// c.RetryPolicy is left at its zero value.
+
+	req := NewRequest("example.com")
+	req.Host = "whois.example.com"
+	res, err := c.fetchWithRetry(context.Background(), req)
+	if err != nil {
+		t.Fatalf("zero-value RetryPolicy must not turn an empty body into an error, got %v", err)
+	}
+	if len(res.Body) != 0 {
+		t.Fatalf("got body %q, want empty", res.Body)
+	}
+	if res.Attempts != 1 {
+		t.Fatalf("got Attempts=%d, want 1", res.Attempts)
+	}
+}
+
+func TestFetchWithRetryExhaustedRetriesIsAnError(t *testing.T) {
+	c := newStubWhoisServer(t, [][]byte{{}, {}, {}})
+	c.RetryPolicy = RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	req := NewRequest("example.com")
+	req.Host = "whois.example.com"
+	if _, err := c.fetchWithRetry(context.Background(), req); err == nil {
+		t.Fatal("expected a still-retryable response after MaxRetries is exhausted to be an error, got nil")
+	}
+}
+
+func TestFetchWithRetrySucceedsAfterTransientEmptyBody(t *testing.T) {
+	c := newStubWhoisServer(t, [][]byte{{}, []byte("whois data")})
+	c.RetryPolicy = RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	req := NewRequest("example.com")
+	req.Host = "whois.example.com"
+	res, err := c.fetchWithRetry(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(res.Body) != "whois data" {
+		t.Fatalf("got body %q, want %q", res.Body, "whois data")
+	}
+	if res.Attempts != 2 {
+		t.Fatalf("got Attempts=%d, want 2", res.Attempts)
+	}
+	if len(res.Errors) != 1 {
+		t.Fatalf("got %d Errors, want 1", len(res.Errors))
+	}
+}
@@ -1,14 +1,28 @@
 package whois
 
-type Server struct {
-	Resolve func(*Request) error
+import "context"
+
+// Server resolves a Request for a particular zone, filling in the host (or
+// URL, for HTTP-based lookups) that Client.Fetch should query. ctx carries
+// the caller's deadline/cancellation for servers that make their own network
+// calls while resolving (e.g. refreshing a bootstrap file).
+type Server interface {
+	Resolve(ctx context.Context, req *Request) error
 }
 
-var Servers = map[string]*Server{}
+var servers = map[string]Server{}
+
+// defaultServer resolves a Request that has no registered Server: the host
+// chosen from the zones table is queried directly over port 43.
+var defaultServer Server = directServer{}
+
+type directServer struct{}
 
-func (server Server) register(names ...string) *Server {
+func (directServer) Resolve(ctx context.Context, req *Request) error { return nil }
+
+func register(server Server, names ...string) Server {
 	for _, name := range names {
-		Servers[name] = &server
+		servers[name] = server
 	}
-	return &server
-}
\ No newline at end of file
+	return server
+}
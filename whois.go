@@ -1,18 +1,25 @@
 package whois
 
 import (
+	"context"
 	"errors"
 	"strings"
 )
 
 // Whois queries a whois server for q and returns the result.
 func Whois(q string) (string, error) {
-	req, err := Resolve(q)
+	return WhoisContext(context.Background(), q)
+}
+
+// WhoisContext queries a whois server for q and returns the result. The
+// lookup is aborted as soon as ctx is canceled or its deadline expires.
+func WhoisContext(ctx context.Context, q string) (string, error) {
+	req, err := ResolveContext(ctx, q)
 	if err != nil {
 		return "", err
 	}
 
-	res, err := req.Fetch()
+	res, err := DefaultClient.FetchContext(ctx, req)
 	if err != nil {
 		return "", err
 	}
@@ -22,22 +29,63 @@ func Whois(q string) (string, error) {
 
 // Resolve finds a whois server for q and prepares a Request.
 func Resolve(q string) (*Request, error) {
+	return ResolveContext(context.Background(), q)
+}
+
+// ResolveContext finds a whois server for q and prepares a Request, passing
+// ctx to the matched Server so it can enforce its own deadline while
+// resolving (e.g. fetching a bootstrap file).
+func ResolveContext(ctx context.Context, q string) (*Request, error) {
 	req := NewRequest(q)
 
 	labels := strings.Split(q, ".")
 	var ok bool
+	var zone string
 	for i := 0; i < len(labels) && !ok; i++ {
-		req.Host, ok = zones[strings.Join(labels[i:], ".")]
+		zone = strings.Join(labels[i:], ".")
+		req.Host, ok = zones[zone]
 	}
 	if !ok {
 		return req, errors.New("No whois server found for " + q)
 	}
+	if extra := mirrors[zone]; len(extra) > 0 {
+		req.Hosts = append([]string{req.Host}, extra...)
+	}
 
 	srv, ok := servers[req.Host]
 	if !ok {
 		srv = defaultServer
 	}
-	srv.Resolve(req)
+	if err := srv.Resolve(ctx, req); err != nil {
+		return req, err
+	}
 
 	return req, nil
 }
+
+// Resolve finds a server for q using c's Mode, like ResolveContext.
+func (c *Client) Resolve(q string) (*Request, error) {
+	return c.ResolveContext(context.Background(), q)
+}
+
+// ResolveContext finds a server for q like the package-level
+// ResolveContext, which only ever consults the classic zones table keyed by
+// dotted-label suffix - a table that a bare IP address or an "ASxxxxx"
+// string can never match. If c.Mode is not WhoisOnly, ResolveContext falls
+// back to a bare Request carrying just Query for any q the zones table
+// doesn't cover, letting Client.FetchContext resolve it directly against
+// RDAP bootstrap data instead of failing outright.
+func (c *Client) ResolveContext(ctx context.Context, q string) (*Request, error) {
+	req, err := ResolveContext(ctx, q)
+	if err == nil || c.Mode == WhoisOnly {
+		return req, err
+	}
+	return NewRequest(q), nil
+}
+
+// mirrors maps a zone to additional candidate whois hosts that should be
+// queried simultaneously alongside the primary host chosen from zones (for
+// example a set of RIRs for IP allocations, or a well-known mirror). Client
+// dials every host in parallel and uses the first successful, non-empty
+// response, canceling the rest.
+var mirrors = map[string][]string{}